@@ -0,0 +1,70 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import "time"
+
+// Clock is a source of time for facilities, such as the sampler, that need
+// to schedule work in the future. The default implementation delegates to
+// the time package; tests that need deterministic scheduling can supply
+// their own, e.g. zapcore/zaptest/clock.Mock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc arranges for f to be called once the duration has elapsed,
+	// returning a Timer that can be used to cancel the call. The system
+	// clock calls f in its own goroutine; other implementations may call it
+	// from elsewhere, e.g. the Mock in zapcore/zaptest/clock calls it from
+	// Add.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer represents a single pending AfterFunc call. Stopping it prevents
+// the associated function from firing, if it hasn't already.
+type Timer interface {
+	// Stop prevents the Timer from firing.
+	Stop()
+}
+
+// newSystemClock returns a Clock backed by the time package.
+func newSystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// realTimer adapts *time.Timer to the Timer interface; its Stop returns a
+// bool, but Timer.Stop doesn't, so this wrapper just discards it.
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) Stop() {
+	t.Timer.Stop()
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import "sync"
+
+// counters3 is a sharded, fixed-capacity counter map: it bounds memory use
+// regardless of key cardinality, while still tracking counts
+// per distinct key (within the capacity of each shard) instead of letting
+// unrelated keys silently collide and share a counter. A workload that logs
+// many unique ent.Message values no longer grows this map without bound.
+const (
+	// total number of tracked keys across all shards.
+	_counters3Capacity = 4096
+
+	// how many shards guard that capacity; each shard is small enough to
+	// scan linearly on every Inc/Reset.
+	_counters3Shards = 64
+
+	_counters3SlotsPerShard = _counters3Capacity / _counters3Shards
+)
+
+func newCounters3() *counters3 {
+	return &counters3{}
+}
+
+type counters3 struct {
+	shards [_counters3Shards]counters3Shard
+}
+
+// counters3Hash hashes every byte of key with 64-bit FNV-1a and permutes the
+// result with xshrr. It doesn't use xorstring, whose output keeps only a
+// key's last 8 bytes: that would give a whole family of templated messages
+// sharing a suffix one hash, and so one shard and one shared slot.
+func counters3Hash(key string) uint32 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return xshrr(h)
+}
+
+func (c *counters3) Inc(key string) uint64 {
+	h := counters3Hash(key)
+	return c.shards[h%_counters3Shards].inc(h)
+}
+
+func (c *counters3) Reset(key string) {
+	h := counters3Hash(key)
+	c.shards[h%_counters3Shards].reset(h)
+}
+
+// counters3Shard is a tiny CLOCK-ish cache: a fixed array of slots, each
+// holding one key's hash, count, and a logical last-touched tick. Inc bumps
+// a matching slot or, on a miss, evicts whichever slot was touched longest
+// ago.
+type counters3Shard struct {
+	sync.Mutex
+
+	clock uint64
+	slots [_counters3SlotsPerShard]counters3Slot
+}
+
+type counters3Slot struct {
+	used        bool
+	hash        uint32
+	count       uint64
+	lastTouched uint64
+}
+
+func (s *counters3Shard) inc(hash uint32) uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	s.clock++
+
+	free := -1
+	oldest := -1
+	for i := range s.slots {
+		slot := &s.slots[i]
+		if slot.used && slot.hash == hash {
+			slot.count++
+			slot.lastTouched = s.clock
+			return slot.count
+		}
+		if !slot.used {
+			free = i
+			continue
+		}
+		if oldest == -1 || slot.lastTouched < s.slots[oldest].lastTouched {
+			oldest = i
+		}
+	}
+
+	i := free
+	if i == -1 {
+		i = oldest
+	}
+	s.slots[i] = counters3Slot{used: true, hash: hash, count: 1, lastTouched: s.clock}
+	return 1
+}
+
+func (s *counters3Shard) reset(hash uint32) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := range s.slots {
+		if s.slots[i].used && s.slots[i].hash == hash {
+			s.slots[i].count = 0
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCounters3_IncAccumulatesPerKey(t *testing.T) {
+	c := newCounters3()
+	if n := c.Inc("foo"); n != 1 {
+		t.Fatalf("want 1, got %d", n)
+	}
+	if n := c.Inc("foo"); n != 2 {
+		t.Fatalf("want 2, got %d", n)
+	}
+	if n := c.Inc("bar"); n != 1 {
+		t.Fatalf("want a distinct key to start its own count at 1, got %d", n)
+	}
+}
+
+func TestCounters3_Reset(t *testing.T) {
+	c := newCounters3()
+	c.Inc("foo")
+	c.Inc("foo")
+	c.Reset("foo")
+	if n := c.Inc("foo"); n != 1 {
+		t.Fatalf("want the count to restart at 1 after Reset, got %d", n)
+	}
+}
+
+// TestCounters3_ShardSpread checks that counters3Hash, which Inc and Reset
+// use, spreads a family of keys sharing a long trailing substring across
+// shards. A hash built on xorstring keeps only the last 8 bytes of a key and
+// would collapse the whole family onto a single shard, defeating the point
+// of sharding for exactly the templated-message case counters3 exists for.
+func TestCounters3_ShardSpread(t *testing.T) {
+	shards := make(map[uint32]bool)
+	for i := 0; i < _counters3Capacity; i++ {
+		key := fmt.Sprintf("user %d logged in", i)
+		h := counters3Hash(key)
+		shards[h%_counters3Shards] = true
+	}
+	if want := _counters3Shards / 2; len(shards) < want {
+		t.Fatalf("want a templated key family to spread across at least %d of the %d shards, got %d", want, _counters3Shards, len(shards))
+	}
+}
+
+// TestCounters3_EvictsOldestOnOverflow fills a single shard past its
+// capacity and checks that the least-recently-touched key is evicted, not
+// an arbitrary or most-recently-used one.
+func TestCounters3_EvictsOldestOnOverflow(t *testing.T) {
+	var sameShard []string
+	var shard uint32
+	haveShard := false
+	for i := 0; len(sameShard) < _counters3SlotsPerShard+1; i++ {
+		if i > 200000 {
+			t.Fatal("couldn't find enough keys hashing to the same shard")
+		}
+		key := fmt.Sprintf("key-%d", i)
+		h := counters3Hash(key) % _counters3Shards
+		if !haveShard {
+			shard, haveShard = h, true
+		}
+		if h == shard {
+			sameShard = append(sameShard, key)
+		}
+	}
+
+	c := newCounters3()
+	for _, key := range sameShard[:len(sameShard)-1] {
+		c.Inc(key)
+	}
+	// sameShard[0] is now the oldest touched slot in the shard; pushing one
+	// more distinct key into the already-full shard must evict it.
+	c.Inc(sameShard[len(sameShard)-1])
+
+	if n := c.Inc(sameShard[0]); n != 1 {
+		t.Fatalf("want the oldest key in an overflowed shard evicted and restarted at 1, got %d", n)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+// SamplerObserver is notified of every sampling decision the sampler makes,
+// so that callers can export sampling pressure to a metrics system without
+// reaching into the sampler's internals. Implementations must be safe for
+// concurrent use, since Check may be called from many goroutines.
+type SamplerObserver interface {
+	// Admitted is called when ent is passed through to the underlying
+	// Facility.
+	Admitted(ent Entry)
+	// Dropped is called when ent is dropped by the sampler. n is the
+	// sampler's running count for ent.Message during the current tick, for
+	// observers that want that context; most observers can ignore it and
+	// simply count the call.
+	Dropped(ent Entry, n uint64)
+}
+
+// nopSamplerObserver is the default SamplerObserver used by Sample and
+// SampleWithClock: it does nothing.
+type nopSamplerObserver struct{}
+
+func (nopSamplerObserver) Admitted(Entry)        {}
+func (nopSamplerObserver) Dropped(Entry, uint64) {}
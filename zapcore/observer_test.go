@@ -0,0 +1,59 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// recordingObserver is a minimal zapcore.SamplerObserver fake that just
+// counts how many times each method fired.
+type recordingObserver struct {
+	admitted int
+	dropped  int
+}
+
+func (o *recordingObserver) Admitted(zapcore.Entry) { o.admitted++ }
+
+func (o *recordingObserver) Dropped(zapcore.Entry, uint64) { o.dropped++ }
+
+func TestSampleWithObserver_NotifiesAdmitAndDrop(t *testing.T) {
+	fake := &countingFacility{}
+	obs := &recordingObserver{}
+	sampled := zapcore.SampleWithObserver(fake, time.Second, 1, 1000000, obs)
+
+	ent := zapcore.Entry{Level: zapcore.DebugLevel, Message: "foo"}
+	sampled.Check(ent, new(zapcore.CheckedEntry)) // within "first": admitted
+	sampled.Check(ent, new(zapcore.CheckedEntry)) // over "first", not the thereafter'th: dropped
+
+	if obs.admitted != 1 {
+		t.Fatalf("want 1 admit notification, got %d", obs.admitted)
+	}
+	if obs.dropped != 1 {
+		t.Fatalf("want 1 drop notification, got %d", obs.dropped)
+	}
+	if fake.admitted != 1 {
+		t.Fatalf("want only the admitted entry reaching the underlying facility, got %d", fake.admitted)
+	}
+}
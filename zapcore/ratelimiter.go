@@ -0,0 +1,168 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"sync"
+	"time"
+)
+
+// _defaultMaxSlackMultiple is how many perRequest intervals of idle credit a
+// leaky bucket may accumulate by default, per RateLimit.
+const _defaultMaxSlackMultiple = 10
+
+// RateLimitOption configures a Facility returned by RateLimit.
+type RateLimitOption interface {
+	apply(*rateLimiter)
+}
+
+type rateLimitOptionFunc func(*rateLimiter)
+
+func (f rateLimitOptionFunc) apply(rl *rateLimiter) { f(rl) }
+
+// MaxSlack overrides the default maximum slack (10 * the per-request
+// interval implied by rps) that a rate limiter may accumulate during idle
+// periods. Larger values allow bigger bursts after idle stretches; smaller
+// values enforce the cap more strictly.
+func MaxSlack(d time.Duration) RateLimitOption {
+	return rateLimitOptionFunc(func(rl *rateLimiter) { rl.maxSlack = d })
+}
+
+// PerKey partitions the rate limit across ent.Message instead of enforcing
+// one global cap, spreading keys over _counters2Width buckets with
+// boundedHash so that no single hot message key serializes unrelated ones on
+// a shared mutex. Distinct keys that hash to the same bucket share its
+// budget.
+func PerKey() RateLimitOption {
+	return rateLimitOptionFunc(func(rl *rateLimiter) { rl.perKey = true })
+}
+
+// RateLimit creates a Facility that admits at most rps entries per second on
+// average, using a leaky-bucket limiter in the style of
+// go.uber.org/ratelimit. A quiet bucket banks idle credit that a later
+// burst can spend at once, but never more than maxSlack of it, so a burst
+// after a long quiet period is still bounded.
+//
+// Because logging must never block the caller, RateLimit does not sleep to
+// enforce the limit. Entries that would require waiting are dropped
+// instead.
+//
+// rps <= 0 disables rate limiting: fac is returned unchanged, so a
+// zero-value config can mean "unlimited" without the caller special-casing
+// it. The same applies if rps is so large that time.Second/rps rounds down
+// to 0, since a zero perRequest interval would likewise admit everything
+// unconditionally.
+func RateLimit(fac Facility, rps int, opts ...RateLimitOption) Facility {
+	if rps <= 0 {
+		return fac
+	}
+
+	perRequest := time.Second / time.Duration(rps)
+	if perRequest <= 0 {
+		return fac
+	}
+	rl := &rateLimiter{
+		Facility:   fac,
+		perRequest: perRequest,
+		maxSlack:   _defaultMaxSlackMultiple * perRequest,
+	}
+	for _, opt := range opts {
+		opt.apply(rl)
+	}
+	if rl.perKey {
+		rl.buckets = &[_counters2Width]rateBucket{}
+	} else {
+		rl.global = &rateBucket{}
+	}
+	return rl
+}
+
+type rateLimiter struct {
+	Facility
+
+	perRequest time.Duration
+	maxSlack   time.Duration
+	perKey     bool
+
+	global  *rateBucket
+	buckets *[_counters2Width]rateBucket
+}
+
+func (r *rateLimiter) With(fields []Field) Facility {
+	clone := *r
+	clone.Facility = r.Facility.With(fields)
+	return &clone
+}
+
+func (r *rateLimiter) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !r.Enabled(ent.Level) {
+		return ce
+	}
+
+	b := r.global
+	if r.perKey {
+		b = &r.buckets[boundedHash(ent.Message, _counters2Width)]
+	}
+	if !b.allow(time.Now(), r.perRequest, r.maxSlack) {
+		return ce
+	}
+	return r.Facility.Check(ent, ce)
+}
+
+// rateBucket is one leaky bucket: lastEmit tracks the time at which the
+// bucket is next willing to admit an entry, accruing at most maxSlack of
+// idle credit.
+type rateBucket struct {
+	sync.Mutex
+	lastEmit time.Time
+}
+
+func (b *rateBucket) allow(now time.Time, perRequest, maxSlack time.Duration) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	last := b.lastEmit
+	if oldest := now.Add(-maxSlack); last.Before(oldest) {
+		// The bucket has been idle long enough to bank more than maxSlack
+		// of credit; clamp it so the idle burst it can spend is bounded.
+		last = oldest
+	}
+	next := last.Add(perRequest)
+
+	// Admitting this entry would require more than perRequest of
+	// already-accrued slack: the caller would have to wait, and logging
+	// must not block, so drop it instead. This has to be decided against
+	// the unclamped next: clamping first and then comparing against
+	// perRequest would admit everything once maxSlack <= perRequest, since
+	// the clamped value is always within perRequest of now by construction.
+	admit := next.Sub(now) <= perRequest
+
+	if slack := next.Sub(now); slack > maxSlack {
+		// Sustained overload has pushed lastEmit further into the future
+		// than we allow; clamp it so recovery time is bounded once the
+		// overload ends. This only affects lastEmit bookkeeping, not the
+		// admit decision above.
+		next = now.Add(maxSlack)
+	}
+	b.lastEmit = next
+
+	return admit
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"testing"
+	"time"
+)
+
+// nopFacility is a Facility that admits everything; it's only used here to
+// check that RateLimit passes it through unchanged for degenerate rps
+// values, not to exercise Check.
+type nopFacility struct{}
+
+func (nopFacility) Enabled(Level) bool                            { return true }
+func (nopFacility) With([]Field) Facility                         { return nopFacility{} }
+func (nopFacility) Check(_ Entry, ce *CheckedEntry) *CheckedEntry { return ce }
+
+func TestRateBucketAllow_SteadyState(t *testing.T) {
+	b := &rateBucket{}
+	base := time.Unix(0, 0)
+	perRequest := 100 * time.Millisecond
+	maxSlack := 10 * perRequest
+
+	for i := 0; i < 5; i++ {
+		now := base.Add(time.Duration(i) * perRequest)
+		if !b.allow(now, perRequest, maxSlack) {
+			t.Fatalf("call %d: want admitted at the steady rate, got dropped", i)
+		}
+	}
+}
+
+func TestRateBucketAllow_BurstAfterIdle(t *testing.T) {
+	b := &rateBucket{}
+	now := time.Unix(100, 0)
+	perRequest := 100 * time.Millisecond
+	maxSlack := 10 * perRequest
+
+	admitted := 0
+	for i := 0; i < 20; i++ {
+		if b.allow(now, perRequest, maxSlack) {
+			admitted++
+		}
+	}
+	// Idle credit is bounded to maxSlack, so only maxSlack/perRequest + 1
+	// of 20 simultaneous calls can be admitted.
+	if want := 11; admitted != want {
+		t.Fatalf("want %d of 20 simultaneous calls admitted after a long idle period, got %d", want, admitted)
+	}
+}
+
+// TestRateBucketAllow_SmallMaxSlackStillLimits is a regression test: a
+// maxSlack at or below perRequest used to make the overload clamp pin
+// next within perRequest of now on every call, admitting everything
+// unconditionally under sustained load instead of enforcing the cap.
+func TestRateBucketAllow_SmallMaxSlackStillLimits(t *testing.T) {
+	b := &rateBucket{}
+	now := time.Unix(200, 0)
+	perRequest := 100 * time.Millisecond
+	maxSlack := 50 * time.Millisecond // smaller than perRequest
+
+	admitted := 0
+	for i := 0; i < 20; i++ {
+		if b.allow(now, perRequest, maxSlack) {
+			admitted++
+		}
+	}
+	if want := 1; admitted != want {
+		t.Fatalf("want exactly %d of 20 simultaneous calls admitted with maxSlack < perRequest, got %d", want, admitted)
+	}
+}
+
+func TestRateLimit_DisablesOnNonPositiveRPS(t *testing.T) {
+	if fac := RateLimit(nopFacility{}, 0); fac != Facility(nopFacility{}) {
+		t.Fatal("want RateLimit(fac, 0) to return fac unchanged")
+	}
+	if fac := RateLimit(nopFacility{}, -5); fac != Facility(nopFacility{}) {
+		t.Fatal("want RateLimit(fac, -5) to return fac unchanged")
+	}
+}
+
+func TestRateLimit_DisablesWhenPerRequestRoundsToZero(t *testing.T) {
+	if fac := RateLimit(nopFacility{}, 2e9); fac != Facility(nopFacility{}) {
+		t.Fatal("want RateLimit to disable itself when rps rounds perRequest down to 0")
+	}
+}
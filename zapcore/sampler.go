@@ -20,110 +20,19 @@
 
 package zapcore
 
-import (
-	"sync"
-	"time"
+import "time"
 
-	"go.uber.org/atomic"
-)
+// _counters2Width is the shard width shared by the per-key facilities that
+// index with boundedHash (currently RateLimit's PerKey buckets); on 64-bit
+// platforms a [8192]rateBucket array takes 256KiB of memory.
+const _counters2Width = 8192
 
-func newCounters() *counters {
-	return &counters{
-		counts: make(map[string]*atomic.Uint64),
-	}
-}
-
-func newCounters2() *counters2 {
-	return &counters2{}
-}
-
-type counters struct {
-	sync.RWMutex
-	counts map[string]*atomic.Uint64
-}
-
-func (c *counters) Inc(key string) uint64 {
-	c.RLock()
-	count, ok := c.counts[key]
-	c.RUnlock()
-	if ok {
-		return count.Inc()
-	}
-
-	c.Lock()
-	count, ok = c.counts[key]
-	if ok {
-		c.Unlock()
-		return count.Inc()
-	}
-
-	c.counts[key] = atomic.NewUint64(1)
-	c.Unlock()
-	return 1
-}
-
-func (c *counters) Reset(key string) {
-	c.Lock()
-	count := c.counts[key]
-	c.Unlock()
-	count.Store(0)
-}
-
-// TODO: replace counters with counters2 once proven
-const (
-	// how many counters are under each lock; 8 uint64s fit in a 64-byte cache
-	// line.
-	_counters2PerLock    = 8
-	_counters2BucketMask = _counters2PerLock - 1
-
-	// target using 64KiB of memory
-	_counters2Width = 8192
-
-	// how many locks we need given those goals
-	_counters2Locks = _counters2Width / _counters2PerLock
-)
-
-type counters2 [_counters2Width]bucket2
-
-type bucket2 struct {
-	sync.Mutex
-	counts [_counters2PerLock]uint64
-}
-
-func (b *bucket2) inc(i uint32, key string) uint64 {
-	var n uint64
-	b.Lock()
-	n = b.counts[i]
-	n++
-	b.counts[i] = n
-	b.Unlock()
-	return n
-}
-
-func (b *bucket2) reset(i uint32, key string) {
-	b.Lock()
-	b.counts[i] = 0
-	b.Unlock()
-}
-
-func (c *counters2) Inc(key string) uint64 {
-	i := c.hash(key)
-	return c[i/_counters2PerLock].inc(i&_counters2BucketMask, key)
-}
-
-func (c *counters2) Reset(key string) {
-	i := c.hash(key)
-	c[i/_counters2PerLock].reset(i&_counters2BucketMask, key)
-}
-
-// hash hashes the key by first xor-collapsing it into a 64-bit state, and then
-// permuting that state with XSH RR (randomly rotated xorshift).
-//
-// TODO: engineer a custom member of the PCG permutation family that targets
-// our actual needed _counters2Width = 9-bit output space; this would avoid the
-// modulo.
-func (c *counters2) hash(key string) uint32 {
-	return xshrr(xorstring(key)) % _counters2Width
+// boundedHash hashes key into the range [0, width) by first xor-collapsing
+// it into a 64-bit state, then permuting that state with XSH RR (randomly
+// rotated xorshift). Because xorstring keeps only the last 8 bytes of key,
+// keys that share their last 8 bytes always get the same index.
+func boundedHash(key string, width uint32) uint32 {
+	return xshrr(xorstring(key)) % width
 }
 
 // xorstring converts a string into a uint64 by xoring together its
@@ -147,14 +56,38 @@ func xshrr(n uint64) uint32 {
 	return (xorshifted >> rot) | (xorshifted << ((-rot) & 31))
 }
 
-// Sample creates a facility that samples incoming entries.
+// Sample creates a facility that samples incoming entries, using the system
+// clock to schedule counter resets.
 func Sample(fac Facility, tick time.Duration, first, thereafter int) Facility {
+	return SampleWithClock(fac, newSystemClock(), tick, first, thereafter)
+}
+
+// SampleWithClock creates a facility that samples incoming entries, using
+// the provided Clock to schedule counter resets instead of the system
+// clock. This is primarily useful in tests, which can supply a
+// zapcore/zaptest/clock.Mock to assert exact sampling boundaries without
+// relying on real sleeps.
+func SampleWithClock(fac Facility, clk Clock, tick time.Duration, first, thereafter int) Facility {
+	return newSampler(fac, clk, tick, first, thereafter, nopSamplerObserver{})
+}
+
+// SampleWithObserver creates a facility that samples incoming entries like
+// Sample, additionally notifying obs of every admit/drop decision. This
+// lets callers export sampling pressure to a metrics system; see
+// zapcore/zapprom for a Prometheus-backed SamplerObserver.
+func SampleWithObserver(fac Facility, tick time.Duration, first, thereafter int, obs SamplerObserver) Facility {
+	return newSampler(fac, newSystemClock(), tick, first, thereafter, obs)
+}
+
+func newSampler(fac Facility, clk Clock, tick time.Duration, first, thereafter int, obs SamplerObserver) Facility {
 	return &sampler{
 		Facility:   fac,
 		tick:       tick,
-		counts:     newCounters(),
+		clk:        clk,
+		counts:     newCounters3(),
 		first:      uint64(first),
 		thereafter: uint64(thereafter),
+		obs:        obs,
 	}
 }
 
@@ -162,18 +95,22 @@ type sampler struct {
 	Facility
 
 	tick       time.Duration
-	counts     *counters
+	clk        Clock
+	counts     *counters3
 	first      uint64
 	thereafter uint64
+	obs        SamplerObserver
 }
 
 func (s *sampler) With(fields []Field) Facility {
 	return &sampler{
 		Facility:   s.Facility.With(fields),
 		tick:       s.tick,
+		clk:        s.clk,
 		counts:     s.counts,
 		first:      s.first,
 		thereafter: s.thereafter,
+		obs:        s.obs,
 	}
 }
 
@@ -183,11 +120,13 @@ func (s *sampler) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
 	}
 	if n := s.counts.Inc(ent.Message); n > s.first {
 		if n == s.first+1 {
-			time.AfterFunc(s.tick, func() { s.counts.Reset(ent.Message) })
+			s.clk.AfterFunc(s.tick, func() { s.counts.Reset(ent.Message) })
 		}
 		if (n-s.first)%s.thereafter != 0 {
+			s.obs.Dropped(ent, n)
 			return ce
 		}
 	}
+	s.obs.Admitted(ent)
 	return s.Facility.Check(ent, ce)
 }
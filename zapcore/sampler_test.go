@@ -0,0 +1,89 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zapcore/zaptest/clock"
+)
+
+// countingFacility is a minimal zapcore.Facility fake that just counts how
+// many entries made it past the sampler.
+type countingFacility struct {
+	admitted int
+}
+
+func (f *countingFacility) Enabled(zapcore.Level) bool { return true }
+
+func (f *countingFacility) With([]zapcore.Field) zapcore.Facility { return f }
+
+func (f *countingFacility) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	f.admitted++
+	return ce
+}
+
+func TestSampleWithClock(t *testing.T) {
+	fake := &countingFacility{}
+	mock := clock.NewMock()
+	sampled := zapcore.SampleWithClock(fake, mock, time.Second, 2, 3)
+
+	ent := zapcore.Entry{Level: zapcore.DebugLevel, Message: "foo"}
+	check := func() { sampled.Check(ent, new(zapcore.CheckedEntry)) }
+
+	// The first two entries in the tick are always admitted.
+	check()
+	check()
+	if fake.admitted != 2 {
+		t.Fatalf("want 2 admitted after the initial burst, got %d", fake.admitted)
+	}
+
+	// The next two are dropped: they fall before the 3rd entry since the
+	// burst, which is the first one SampleWithClock lets through again.
+	check()
+	check()
+	if fake.admitted != 2 {
+		t.Fatalf("want still 2 admitted before the thereafter'th entry, got %d", fake.admitted)
+	}
+
+	// The 3rd entry since the burst is admitted.
+	check()
+	if fake.admitted != 3 {
+		t.Fatalf("want the thereafter'th entry admitted, got %d", fake.admitted)
+	}
+
+	// A different message has its own counter, so it isn't affected by
+	// foo's count.
+	sampled.Check(zapcore.Entry{Level: zapcore.DebugLevel, Message: "bar"}, new(zapcore.CheckedEntry))
+	if fake.admitted != 4 {
+		t.Fatalf("want a fresh message to be admitted, got %d", fake.admitted)
+	}
+
+	// Advancing the mock clock past the tick resets foo's counter
+	// deterministically, without a real sleep.
+	mock.Add(time.Second)
+	check()
+	if fake.admitted != 5 {
+		t.Fatalf("want the tick reset to admit foo again, got %d", fake.admitted)
+	}
+}
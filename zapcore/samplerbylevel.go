@@ -0,0 +1,155 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore
+
+import (
+	"sync"
+	"time"
+)
+
+// SamplingPolicy is the (first, thereafter) pair applied to one level by
+// SampleByLevel: the first First entries with a given message in a tick are
+// always admitted, and after that only every Thereafter'th one is. The zero
+// value (and any policy with Thereafter <= 0) disables sampling for that
+// level entirely, passing every entry straight through; this is the usual
+// way to exempt ERROR and above from sampling.
+type SamplingPolicy struct {
+	First      int
+	Thereafter int
+}
+
+func (p SamplingPolicy) disabled() bool {
+	return p.Thereafter <= 0
+}
+
+// SampleByLevel creates a facility that samples incoming entries like
+// Sample, but applies a different SamplingPolicy per level instead of one
+// policy for everything. Levels without an entry in policies use fallback.
+// Each level's counters are tracked independently, so a burst of one
+// level's messages can never suppress admission of another level's
+// identically-worded ones.
+func SampleByLevel(fac Facility, tick time.Duration, policies map[Level]SamplingPolicy, fallback SamplingPolicy) Facility {
+	lp := &levelPolicies{
+		byLevel:  make(map[Level]*levelCounts, len(policies)),
+		fallback: fallback,
+	}
+	for lvl, p := range policies {
+		lp.byLevel[lvl] = newLevelCounts(p)
+	}
+
+	return &samplerByLevel{
+		Facility: fac,
+		tick:     tick,
+		clk:      newSystemClock(),
+		obs:      nopSamplerObserver{},
+		policies: lp,
+	}
+}
+
+// levelCounts pairs a SamplingPolicy with the counters it needs, or no
+// counters at all if the policy disables sampling for its level.
+type levelCounts struct {
+	policy SamplingPolicy
+	counts *counters3
+}
+
+func newLevelCounts(p SamplingPolicy) *levelCounts {
+	lc := &levelCounts{policy: p}
+	if !p.disabled() {
+		lc.counts = newCounters3()
+	}
+	return lc
+}
+
+// levelPolicies is the shared, lockable state behind a samplerByLevel; it's
+// held by pointer so that clones made by With still see the same per-level
+// counters and fallback-derived policies as the original.
+type levelPolicies struct {
+	mu       sync.RWMutex
+	byLevel  map[Level]*levelCounts
+	fallback SamplingPolicy
+}
+
+// forLevel returns the levelCounts configured for lvl, creating one from
+// the fallback policy on first use if the caller didn't configure lvl
+// explicitly.
+func (lp *levelPolicies) forLevel(lvl Level) *levelCounts {
+	lp.mu.RLock()
+	lc, ok := lp.byLevel[lvl]
+	lp.mu.RUnlock()
+	if ok {
+		return lc
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	if lc, ok = lp.byLevel[lvl]; ok {
+		return lc
+	}
+	lc = newLevelCounts(lp.fallback)
+	lp.byLevel[lvl] = lc
+	return lc
+}
+
+type samplerByLevel struct {
+	Facility
+
+	tick     time.Duration
+	clk      Clock
+	obs      SamplerObserver
+	policies *levelPolicies
+}
+
+func (s *samplerByLevel) With(fields []Field) Facility {
+	return &samplerByLevel{
+		Facility: s.Facility.With(fields),
+		tick:     s.tick,
+		clk:      s.clk,
+		obs:      s.obs,
+		policies: s.policies,
+	}
+}
+
+func (s *samplerByLevel) Check(ent Entry, ce *CheckedEntry) *CheckedEntry {
+	if !s.Enabled(ent.Level) {
+		return ce
+	}
+
+	lc := s.policies.forLevel(ent.Level)
+	if lc.counts == nil {
+		s.obs.Admitted(ent)
+		return s.Facility.Check(ent, ce)
+	}
+
+	first := uint64(lc.policy.First)
+	thereafter := uint64(lc.policy.Thereafter)
+	if n := lc.counts.Inc(ent.Message); n > first {
+		if n == first+1 {
+			s.clk.AfterFunc(s.tick, func() { lc.counts.Reset(ent.Message) })
+		}
+		if (n-first)%thereafter != 0 {
+			s.obs.Dropped(ent, n)
+			return ce
+		}
+	}
+	s.obs.Admitted(ent)
+	return s.Facility.Check(ent, ce)
+}
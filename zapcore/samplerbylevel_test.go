@@ -0,0 +1,86 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zapcore_test
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampleByLevel_PerLevelIsolation(t *testing.T) {
+	fake := &countingFacility{}
+	policies := map[zapcore.Level]zapcore.SamplingPolicy{
+		zapcore.DebugLevel: {First: 1, Thereafter: 1000000},
+	}
+	fallback := zapcore.SamplingPolicy{First: 1, Thereafter: 1000000}
+	sampled := zapcore.SampleByLevel(fake, time.Second, policies, fallback)
+
+	debugEnt := zapcore.Entry{Level: zapcore.DebugLevel, Message: "same text"}
+	infoEnt := zapcore.Entry{Level: zapcore.InfoLevel, Message: "same text"}
+
+	// Burn through DEBUG's budget for this message; only the first call
+	// should be admitted given Thereafter is effectively infinite here.
+	for i := 0; i < 6; i++ {
+		sampled.Check(debugEnt, new(zapcore.CheckedEntry))
+	}
+	debugAdmitted := fake.admitted
+	if debugAdmitted != 1 {
+		t.Fatalf("want only the first DEBUG entry admitted, got %d", debugAdmitted)
+	}
+
+	// An identically-worded INFO entry must not be affected by DEBUG's
+	// counter: counters are partitioned per level.
+	sampled.Check(infoEnt, new(zapcore.CheckedEntry))
+	if fake.admitted != debugAdmitted+1 {
+		t.Fatalf("want an identically-worded INFO entry unaffected by DEBUG's count, got %d admitted (was %d)", fake.admitted, debugAdmitted)
+	}
+}
+
+func TestSampleByLevel_DisabledPolicyPassesThrough(t *testing.T) {
+	fake := &countingFacility{}
+	policies := map[zapcore.Level]zapcore.SamplingPolicy{
+		zapcore.ErrorLevel: {}, // zero value disables sampling for ERROR
+	}
+	sampled := zapcore.SampleByLevel(fake, time.Second, policies, zapcore.SamplingPolicy{First: 1, Thereafter: 2})
+
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, Message: "boom"}
+	for i := 0; i < 10; i++ {
+		sampled.Check(ent, new(zapcore.CheckedEntry))
+	}
+	if fake.admitted != 10 {
+		t.Fatalf("want every ERROR entry admitted when its policy disables sampling, got %d of 10", fake.admitted)
+	}
+}
+
+func TestSampleByLevel_FallbackAppliesToUnlistedLevels(t *testing.T) {
+	fake := &countingFacility{}
+	sampled := zapcore.SampleByLevel(fake, time.Second, nil, zapcore.SamplingPolicy{First: 1, Thereafter: 1000000})
+
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Message: "same text"}
+	for i := 0; i < 5; i++ {
+		sampled.Check(ent, new(zapcore.CheckedEntry))
+	}
+	if fake.admitted != 1 {
+		t.Fatalf("want the fallback policy applied to a level with no explicit entry, got %d admitted", fake.admitted)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zapprom implements a zapcore.SamplerObserver that reports
+// sampling decisions as Prometheus counters, so operators can alert on and
+// graph sampling pressure without reaching into zap internals.
+package zapprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Option configures an Observer built by NewObserver.
+type Option interface {
+	apply(*Observer)
+}
+
+type optionFunc func(*Observer)
+
+func (f optionFunc) apply(o *Observer) { f(o) }
+
+// WithMessageLabel adds the sampled entry's message as a "message" label on
+// both counters. It's off by default: messages are often high-cardinality
+// (templated errors, request IDs), and an unbounded label value can blow up
+// a Prometheus registry. Only enable it when the sampled messages are drawn
+// from a small, known set.
+func WithMessageLabel() Option {
+	return optionFunc(func(o *Observer) { o.withMessage = true })
+}
+
+// Observer implements zapcore.SamplerObserver by incrementing
+// prometheus.CounterVecs labeled by level (and, optionally, message).
+type Observer struct {
+	admitted    *prometheus.CounterVec
+	dropped     *prometheus.CounterVec
+	withMessage bool
+}
+
+// NewObserver builds an Observer and registers its counters with reg.
+func NewObserver(reg prometheus.Registerer, opts ...Option) (*Observer, error) {
+	o := &Observer{}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	labels := []string{"level"}
+	if o.withMessage {
+		labels = append(labels, "message")
+	}
+
+	o.admitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "zap_sampler_admitted_total",
+		Help: "Number of log entries admitted by a zapcore sampler.",
+	}, labels)
+	o.dropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "zap_sampler_dropped_total",
+		Help: "Number of log entries dropped by a zapcore sampler.",
+	}, labels)
+
+	for _, c := range []*prometheus.CounterVec{o.admitted, o.dropped} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+func (o *Observer) labels(ent zapcore.Entry) prometheus.Labels {
+	l := prometheus.Labels{"level": ent.Level.String()}
+	if o.withMessage {
+		l["message"] = ent.Message
+	}
+	return l
+}
+
+// Admitted implements zapcore.SamplerObserver.
+func (o *Observer) Admitted(ent zapcore.Entry) {
+	o.admitted.With(o.labels(ent)).Inc()
+}
+
+// Dropped implements zapcore.SamplerObserver.
+func (o *Observer) Dropped(ent zapcore.Entry, _ uint64) {
+	o.dropped.With(o.labels(ent)).Inc()
+}
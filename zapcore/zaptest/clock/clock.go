@@ -0,0 +1,122 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package clock provides a zapcore.Clock double for deterministically
+// testing facilities, such as the sampler, that schedule work relative to
+// time passing.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Mock is a zapcore.Clock that never advances on its own. Tests drive it
+// forward explicitly with Add, which fires every pending AfterFunc callback
+// whose deadline the advance has reached, in deadline order.
+//
+// The zero value is not usable; construct one with NewMock.
+type Mock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers mockTimers
+}
+
+// NewMock returns a Mock initialized to the current wall-clock time. The
+// starting time is arbitrary: what matters for most tests is the relative
+// offsets passed to Add.
+func NewMock() *Mock {
+	return &Mock{now: time.Now()}
+}
+
+// Now returns the mock's current time.
+func (m *Mock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// AfterFunc schedules f to run once the mock has been advanced by at least
+// d, and returns a Timer that can cancel it beforehand.
+func (m *Mock) AfterFunc(d time.Duration, f func()) zapcore.Timer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := &mockTimer{deadline: m.now.Add(d), f: f}
+	m.timers = append(m.timers, t)
+	return t
+}
+
+// Add moves the clock forward by d, synchronously calling every scheduled
+// function whose deadline has been reached, in order of deadline. It
+// returns once all of them have run.
+func (m *Mock) Add(d time.Duration) {
+	m.mu.Lock()
+	end := m.now.Add(d)
+	sort.Sort(m.timers)
+
+	due := m.timers[:0:0]
+	var pending mockTimers
+	for _, t := range m.timers {
+		if !t.stopped() && !t.deadline.After(end) {
+			due = append(due, t)
+			continue
+		}
+		if !t.stopped() {
+			pending = append(pending, t)
+		}
+	}
+	m.timers = pending
+	m.now = end
+	m.mu.Unlock()
+
+	for _, t := range due {
+		t.f()
+	}
+}
+
+type mockTimer struct {
+	deadline time.Time
+	f        func()
+
+	mu        sync.Mutex
+	isStopped bool
+}
+
+func (t *mockTimer) Stop() {
+	t.mu.Lock()
+	t.isStopped = true
+	t.mu.Unlock()
+}
+
+func (t *mockTimer) stopped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isStopped
+}
+
+type mockTimers []*mockTimer
+
+func (s mockTimers) Len() int           { return len(s) }
+func (s mockTimers) Less(i, j int) bool { return s[i].deadline.Before(s[j].deadline) }
+func (s mockTimers) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }